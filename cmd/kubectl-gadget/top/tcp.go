@@ -28,6 +28,7 @@ import (
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	celfilter "github.com/inspektor-gadget/inspektor-gadget/pkg/expr/cel"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/tcp/types"
 )
@@ -39,6 +40,10 @@ type TCPParser struct {
 	flags     *CommonTopFlags
 	nodeStats map[string][]*types.Stats
 	colMap    columns.ColumnMap[types.Stats]
+
+	filter     *celfilter.Filter[types.Stats]
+	project    *celfilter.Projection[types.Stats]
+	errLimiter celfilter.ErrorRateLimiter
 }
 
 func newTCPCmd() *cobra.Command {
@@ -67,6 +72,8 @@ func newTCPCmd() *cobra.Command {
 	var (
 		filteredPid uint
 		family      uint
+		filterExpr  string
+		projectExpr string
 	)
 
 	columnsWidth := map[string]int{
@@ -81,6 +88,7 @@ func newTCPCmd() *cobra.Command {
 		"daddr":     -51,
 		"sent":      -7,
 		"received":  -7,
+		"expr":      -20,
 	}
 
 	cols := columns.MustCreateColumns[types.Stats]()
@@ -97,6 +105,26 @@ func newTCPCmd() *cobra.Command {
 
 			parser.colMap = cols.GetColumnMap()
 
+			if filterExpr != "" {
+				filter, err := celfilter.CompileFilter[types.Stats](parser.colMap, filterExpr)
+				if err != nil {
+					return commonutils.WrapInErrInvalidArg("--filter-expr", err)
+				}
+				parser.filter = filter
+			}
+
+			if projectExpr != "" {
+				project, err := celfilter.CompileProjection[types.Stats](parser.colMap, projectExpr)
+				if err != nil {
+					return commonutils.WrapInErrInvalidArg("--project-expr", err)
+				}
+				parser.project = project
+
+				if !contains(parser.OutputConfig.CustomColumns, "expr") {
+					parser.OutputConfig.CustomColumns = append(parser.OutputConfig.CustomColumns, "expr")
+				}
+			}
+
 			parameters := make(map[string]string)
 			if family != 0 {
 				parameters[types.FamilyParam] = strconv.FormatUint(uint64(family), 10)
@@ -134,10 +162,31 @@ func newTCPCmd() *cobra.Command {
 		0,
 		"Show only TCP events for this IP version: either 4 or 6 (by default all will be printed)",
 	)
+	cmd.PersistentFlags().StringVar(
+		&filterExpr,
+		"filter-expr",
+		"",
+		"Only show events matching this CEL expression, e.g. --filter-expr 'stats.sent > 1024 && stats.pid != 0'",
+	)
+	cmd.PersistentFlags().StringVar(
+		&projectExpr,
+		"project-expr",
+		"",
+		"Add a computed 'expr' column holding the result of this CEL expression, evaluated per event",
+	)
 
 	return cmd
 }
 
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *TCPParser) Callback(line string, node string) {
 	p.Lock()
 	defer p.Unlock()
@@ -154,7 +203,25 @@ func (p *TCPParser) Callback(line string, node string) {
 		return
 	}
 
-	p.nodeStats[node] = event.Stats
+	if p.filter == nil {
+		p.nodeStats[node] = event.Stats
+		return
+	}
+
+	stats := make([]*types.Stats, 0, len(event.Stats))
+	for _, stat := range event.Stats {
+		keep, err := p.filter.Eval(stat)
+		if err != nil {
+			if p.errLimiter.Allow() {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			}
+			continue
+		}
+		if keep {
+			stats = append(stats, stat)
+		}
+	}
+	p.nodeStats[node] = stats
 }
 
 func (p *TCPParser) PrintStats() {
@@ -212,6 +279,19 @@ func (p *TCPParser) TransformStats(stats *types.Stats) string {
 				sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], stats.Sent/1024))
 			case "received":
 				sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], stats.Received/1024))
+			case "expr":
+				var value string
+				if p.project != nil {
+					var err error
+					value, err = p.project.Eval(stats)
+					if err != nil {
+						if p.errLimiter.Allow() {
+							fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+						}
+						value = ""
+					}
+				}
+				sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], value))
 			}
 			sb.WriteRune(' ')
 		}
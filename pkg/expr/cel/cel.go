@@ -0,0 +1,309 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cel lets gadgets compile and evaluate user supplied CEL
+// (Common Expression Language) expressions over the fields of a columns.Columns
+// event type, such as `stats.sent > 1024 && stats.saddr.startsWith("10.")`.
+//
+// All columns are exposed through a single top-level "stats" variable (a CEL
+// map), so expressions always reference fields as stats.<column>, matching
+// the --filter-expr/--project-expr documentation.
+//
+// It is currently wired into `top tcp` and, for TypeString operator params,
+// the ebpf operator; the block-io and file top parsers don't exist in this
+// tree yet, so they aren't wired up.
+package cel
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+// RootVar is the name of the single top-level CEL variable that exposes the
+// event's columns, e.g. `stats.sent > 1024`.
+const RootVar = "stats"
+
+var (
+	ipType   = reflect.TypeOf(net.IP{})
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// fieldAccessor knows how to pull the value of a single column out of an
+// event of type T and hand it to CEL as a native Go value.
+type fieldAccessor struct {
+	index   []int
+	convert func(reflect.Value) (any, error)
+}
+
+// Filter is a compiled CEL expression that evaluates to a boolean for a given
+// event. It is safe for concurrent use.
+type Filter[T any] struct {
+	expr      string
+	program   cel.Program
+	accessors map[string]fieldAccessor
+}
+
+// Projection is a compiled CEL expression that computes a derived value for a
+// given event. Unlike Filter it isn't restricted to a boolean result; the
+// result is rendered with fmt.Sprint. It is safe for concurrent use.
+type Projection[T any] struct {
+	expr      string
+	program   cel.Program
+	accessors map[string]fieldAccessor
+}
+
+// CompileFilter compiles expr against the columns of T. It fails at compile
+// time, not at evaluation time, so callers can surface a clear error to the
+// user while parsing flags.
+func CompileFilter[T any](colMap columns.ColumnMap[T], expr string) (*Filter[T], error) {
+	program, accessors, err := compile[T](colMap, expr, cel.BoolType)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter[T]{expr: expr, program: program, accessors: accessors}, nil
+}
+
+// Eval reports whether entry matches the filter expression.
+func (f *Filter[T]) Eval(entry *T) (bool, error) {
+	out, err := eval(entry, f.program, f.accessors)
+	if err != nil {
+		return false, fmt.Errorf("evaluating filter expression %q: %w", f.expr, err)
+	}
+	b, ok := out.(bool)
+	if !ok {
+		return false, fmt.Errorf("filter expression %q did not evaluate to a bool", f.expr)
+	}
+	return b, nil
+}
+
+// CompileProjection compiles expr against the columns of T. Unlike
+// CompileFilter it doesn't constrain the output type of the expression.
+func CompileProjection[T any](colMap columns.ColumnMap[T], expr string) (*Projection[T], error) {
+	program, accessors, err := compile[T](colMap, expr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Projection[T]{expr: expr, program: program, accessors: accessors}, nil
+}
+
+// Eval computes the projected value for entry, formatted as a string.
+func (p *Projection[T]) Eval(entry *T) (string, error) {
+	out, err := eval(entry, p.program, p.accessors)
+	if err != nil {
+		return "", fmt.Errorf("evaluating project expression %q: %w", p.expr, err)
+	}
+	return fmt.Sprint(out), nil
+}
+
+func eval[T any](entry *T, program cel.Program, accessors map[string]fieldAccessor) (any, error) {
+	stats := make(map[string]any, len(accessors))
+	v := reflect.ValueOf(entry).Elem()
+	for name, acc := range accessors {
+		val, err := acc.convert(v.FieldByIndex(acc.index))
+		if err != nil {
+			return nil, fmt.Errorf("reading column %q: %w", name, err)
+		}
+		stats[name] = val
+	}
+
+	out, _, err := program.Eval(map[string]any{RootVar: stats})
+	if err != nil {
+		return nil, err
+	}
+	return out.Value(), nil
+}
+
+type cacheKey struct {
+	typ  reflect.Type
+	expr string
+	want *cel.Type
+}
+
+type cacheEntry struct {
+	program   cel.Program
+	accessors map[string]fieldAccessor
+}
+
+var (
+	compileCacheMu sync.Mutex
+	compileCache   = map[cacheKey]*cacheEntry{}
+)
+
+// compile builds a cel.Env exposing colMap's columns through the "stats"
+// variable, then compiles and programs expr. Compiled programs are cached by
+// (event type, expression string, wanted output type) so that repeatedly parsing the same flag
+// value, e.g. across multiple gadget instances, is cheap. If want is
+// non-nil, expr is rejected unless it evaluates to that type.
+func compile[T any](colMap columns.ColumnMap[T], expr string, want *cel.Type) (cel.Program, map[string]fieldAccessor, error) {
+	var zero T
+	key := cacheKey{typ: reflect.TypeOf(zero), expr: expr, want: want}
+
+	compileCacheMu.Lock()
+	if entry, ok := compileCache[key]; ok {
+		compileCacheMu.Unlock()
+		return entry.program, entry.accessors, nil
+	}
+	compileCacheMu.Unlock()
+
+	accessors, err := newFieldAccessors(colMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable(RootVar, cel.MapType(cel.StringType, cel.DynType)),
+		// Columns are reflected from Go types that don't all share a width
+		// (uint64 sent/received vs. an untyped int literal like 1024), so
+		// comparisons need to work across numeric types.
+		cel.CrossTypeNumericComparisons(true),
+		// startsWith/endsWith/contains etc. used in saddr/daddr matching.
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating CEL environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, nil, fmt.Errorf("compiling expression %q: %w", expr, issues.Err())
+	}
+
+	if want != nil && ast.OutputType() != want {
+		return nil, nil, fmt.Errorf("expression %q must evaluate to %s, got %s", expr, want, ast.OutputType())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building program for expression %q: %w", expr, err)
+	}
+
+	compileCacheMu.Lock()
+	compileCache[key] = &cacheEntry{program: program, accessors: accessors}
+	compileCacheMu.Unlock()
+
+	return program, accessors, nil
+}
+
+// newFieldAccessors walks the columns in colMap, locates the corresponding
+// field of T via its `column` struct tag and builds a fieldAccessor for each
+// one that maps to a type CEL understands. Columns backed by computed or
+// unsupported Go types are silently left out of the stats map rather than
+// failing the whole expression.
+func newFieldAccessors[T any](colMap columns.ColumnMap[T]) (map[string]fieldAccessor, error) {
+	var zero T
+	t := reflect.TypeOf(zero)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a struct", zero)
+	}
+
+	accessors := make(map[string]fieldAccessor, len(colMap))
+
+	for name := range colMap {
+		index, fieldType, ok := findColumnField(t, name)
+		if !ok {
+			continue
+		}
+
+		convert, ok := converterFor(fieldType)
+		if !ok {
+			continue
+		}
+
+		accessors[name] = fieldAccessor{index: index, convert: convert}
+	}
+
+	return accessors, nil
+}
+
+// findColumnField looks for a field of t tagged `column:"name"`, descending
+// into embedded structs the same way the columns package does.
+func findColumnField(t reflect.Type, name string) ([]int, reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, _, _ := strings.Cut(field.Tag.Get("column"), ",")
+		if tag == name {
+			return []int{i}, field.Type, true
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if index, fieldType, ok := findColumnField(field.Type, name); ok {
+				return append([]int{i}, index...), fieldType, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// converterFor returns a function that turns a reflect.Value of type t into
+// the native Go value CEL's default type adapter expects inside the stats
+// map, or false if t isn't a type this package knows how to expose to CEL.
+func converterFor(t reflect.Type) (func(reflect.Value) (any, error), bool) {
+	switch t {
+	case ipType:
+		return func(v reflect.Value) (any, error) {
+			return v.Interface().(net.IP).String(), nil
+		}, true
+	case timeType:
+		return func(v reflect.Value) (any, error) {
+			return v.Interface().(time.Time), nil
+		}, true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(v reflect.Value) (any, error) { return v.String(), nil }, true
+	case reflect.Bool:
+		return func(v reflect.Value) (any, error) { return v.Bool(), nil }, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) (any, error) { return v.Int(), nil }, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value) (any, error) { return v.Uint(), nil }, true
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) (any, error) { return v.Float(), nil }, true
+	}
+
+	return nil, false
+}
+
+// ErrorRateLimiter throttles how often a caller reports CEL evaluation
+// errors, so that a single misbehaving expression can't flood the logs with
+// one line per event. It is safe for concurrent use.
+type ErrorRateLimiter struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// Allow reports whether the caller should log the next evaluation error. It
+// returns true at most once per second.
+func (r *ErrorRateLimiter) Allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.last) < time.Second {
+		return false
+	}
+	r.last = now
+	return true
+}
@@ -15,11 +15,14 @@
 package ebpfoperator
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"github.com/cilium/ebpf/btf"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/btfhelpers"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	celfilter "github.com/inspektor-gadget/inspektor-gadget/pkg/expr/cel"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
 	ebpftypes "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/ebpf/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
@@ -30,9 +33,32 @@ type param struct {
 	fromEbpf bool
 	// Only valid for string parameters
 	strLen int
+	// Only valid for TypeBytes parameters
+	byteLen int
+	// celFilter is set, via the "celFilter: true" metadata key, for a
+	// TypeString param whose value should be compiled as a CEL filter
+	// expression (see pkg/expr/cel) rather than treated as a literal.
+	celFilter bool
 }
 
-func getTypeHint(typ btf.Type) params.TypeHint {
+// CompileFilter compiles value, the value of param p, as a CEL filter
+// expression over T's columns. It is only meaningful for TypeString params created with the
+// "celFilter: true" metadata key; calling it otherwise returns an error.
+func CompileFilter[T any](colMap columns.ColumnMap[T], p *param, value string) (*celfilter.Filter[T], error) {
+	if !p.celFilter {
+		return nil, fmt.Errorf("param %q is not a CEL filter param", p.Key)
+	}
+	return celfilter.CompileFilter[T](colMap, value)
+}
+
+// getTypeHint maps a BTF type to the params.TypeHint used to represent it on
+// the CLI/API. member is non-nil when typ is the type of a struct member,
+// which is needed to detect bitfields; it is nil for plain variables.
+func getTypeHint(typ btf.Type, member *btf.Member) params.TypeHint {
+	if member != nil && member.BitfieldSize == 1 {
+		return params.TypeBool
+	}
+
 	typ = btfhelpers.ResolveType(typ)
 
 	switch typedMember := typ.(type) {
@@ -72,21 +98,74 @@ func getTypeHint(typ btf.Type) params.TypeHint {
 		case 8:
 			return params.TypeFloat64
 		}
+	case *btf.Enum:
+		return params.TypeEnum
+	case *btf.Enum64:
+		return params.TypeEnum
 	case *btf.Struct:
 		switch typedMember.Name {
 		case ebpftypes.L3EndpointTypeName:
 			return params.TypeIP
+		case ebpftypes.L4EndpointTypeName:
+			return params.TypeL4Endpoint
 		}
 	case *btf.Array:
 		arrayType := btfhelpers.ResolveType(typedMember.Type)
 		if arrayType.TypeName() == "char" {
 			return params.TypeString
 		}
+		// Fixed-size byte arrays, e.g. u8[16]/u8[4], default to raw bytes;
+		// addParam upgrades this to params.TypeIP when the metadata
+		// annotates the param as holding an address family.
+		if intType, ok := arrayType.(*btf.Int); ok && intType.Size == 1 {
+			return params.TypeBytes
+		}
 	}
 
 	return params.TypeUnknown
 }
 
+// enumeratorNames returns the valid enumerator names for an enum type, used
+// both to populate api.Param.PossibleValues and to validate a metadata
+// defaultValue.
+func enumeratorNames(typ btf.Type) []string {
+	switch e := typ.(type) {
+	case *btf.Enum:
+		names := make([]string, 0, len(e.Values))
+		for _, v := range e.Values {
+			names = append(names, v.Name)
+		}
+		return names
+	case *btf.Enum64:
+		names := make([]string, 0, len(e.Values))
+		for _, v := range e.Values {
+			names = append(names, v.Name)
+		}
+		return names
+	}
+	return nil
+}
+
+// enumeratorForValue returns the name of the enumerator of typ whose value
+// equals val, if any.
+func enumeratorForValue(typ btf.Type, val int64) (string, bool) {
+	switch e := typ.(type) {
+	case *btf.Enum:
+		for _, v := range e.Values {
+			if int64(v.Value) == val {
+				return v.Name, true
+			}
+		}
+	case *btf.Enum64:
+		for _, v := range e.Values {
+			if int64(v.Value) == val {
+				return v.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
 func (i *ebpfInstance) populateParam(t btf.Type, varName string) error {
 	if _, found := i.params[varName]; found {
 		i.logger.Debugf("param %q already defined, skipping", varName)
@@ -99,36 +178,86 @@ func (i *ebpfInstance) populateParam(t btf.Type, varName string) error {
 		return fmt.Errorf("no BTF type found for: %s: %w", varName, err)
 	}
 
+	return i.addParam(varName, varName, btfVar.Type, nil, 0)
+}
+
+// addParam creates the params for varName, recursing into nested structs
+// (other than the known endpoint types) so that each leaf field of the
+// struct becomes its own "<varName>.<field>" param. key is the dotted path
+// used both as the param's map key and, by default, its api.Param.Key.
+// offset is the byte offset of typ inside the .rodata section, used to read
+// the current value for enum defaults.
+func (i *ebpfInstance) addParam(varName, key string, typ btf.Type, member *btf.Member, offset uint32) error {
+	resolved := btfhelpers.ResolveType(typ)
+
+	if structType, ok := resolved.(*btf.Struct); ok {
+		switch structType.Name {
+		case ebpftypes.L3EndpointTypeName, ebpftypes.L4EndpointTypeName:
+			// Handled as a single leaf param below.
+		default:
+			for _, m := range structType.Members {
+				m := m
+				if err := i.addParam(varName, key+"."+m.Name, m.Type, &m, offset+uint32(m.Offset.Bytes())); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
 	newParam := &param{
 		Param: &api.Param{
-			Key: varName,
+			Key: key,
 		},
 		fromEbpf: true,
 	}
 
-	th := getTypeHint(btfVar.Type)
+	th := getTypeHint(resolved, member)
+	// Fill additional information from metadata
+	paramInfo := i.config.Sub("params.ebpf." + key)
+	if paramInfo == nil && key == varName {
+		// Backward compatibility
+		paramInfo = i.config.Sub("ebpfParams." + varName)
+	}
+
+	if th == params.TypeBytes && paramInfo != nil && paramInfo.GetString("family") != "" {
+		th = params.TypeIP
+	}
 	newParam.TypeHint = string(th)
-	if th == params.TypeString {
-		typ := btfhelpers.ResolveType(btfVar.Type)
-		if arrayType, ok := typ.(*btf.Array); ok {
+
+	switch th {
+	case params.TypeString:
+		if arrayType, ok := resolved.(*btf.Array); ok {
 			newParam.strLen = int(arrayType.Nelems)
 		}
+		if paramInfo != nil && paramInfo.GetBool("celFilter") {
+			newParam.celFilter = true
+		}
+	case params.TypeBytes:
+		if arrayType, ok := resolved.(*btf.Array); ok {
+			newParam.byteLen = int(arrayType.Nelems)
+		}
+	case params.TypeEnum:
+		newParam.PossibleValues = enumeratorNames(resolved)
+		if name, err := i.enumDefaultValue(resolved, varName, offset); err != nil {
+			i.logger.Debugf("reading default value for enum param %q: %s", key, err)
+		} else if name != "" {
+			newParam.DefaultValue = name
+		}
 	}
 
-	i.logger.Debugf("adding param %q (%v)", btfVar.Name, th)
+	i.logger.Debugf("adding param %q (%v)", key, th)
 
-	// Fill additional information from metadata
-	paramInfo := i.config.Sub("params.ebpf." + varName)
-	if paramInfo == nil {
-		// Backward compatibility
-		paramInfo = i.config.Sub("ebpfParams." + varName)
-	}
 	if paramInfo != nil {
 		i.logger.Debugf(" filling additional information from metadata")
 		if s := paramInfo.GetString("key"); s != "" {
 			newParam.Key = s
 		}
 		if s := paramInfo.GetString("defaultValue"); s != "" {
+			if th == params.TypeEnum && !contains(newParam.PossibleValues, s) {
+				return fmt.Errorf("default value %q for enum param %q is not a valid enumerator (possible values: %v)",
+					s, key, newParam.PossibleValues)
+			}
 			newParam.DefaultValue = s
 		}
 		if s := paramInfo.GetString("description"); s != "" {
@@ -136,6 +265,111 @@ func (i *ebpfInstance) populateParam(t btf.Type, varName string) error {
 		}
 	}
 
-	i.params[varName] = newParam
+	i.params[key] = newParam
 	return nil
 }
+
+// enumDefaultValue reads the current value of varName's .rodata entry and
+// returns the name of the enumerator it matches, if any.
+func (i *ebpfInstance) enumDefaultValue(typ btf.Type, varName string, offset uint32) (string, error) {
+	size, signed, err := enumSize(typ)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := i.rodataBytes(varName, offset, size)
+	if err != nil {
+		return "", err
+	}
+
+	val := decodeInt(data, signed)
+	name, ok := enumeratorForValue(typ, val)
+	if !ok {
+		return "", nil
+	}
+	return name, nil
+}
+
+func enumSize(typ btf.Type) (int, bool, error) {
+	switch e := typ.(type) {
+	case *btf.Enum:
+		return int(e.Size), e.Signed, nil
+	case *btf.Enum64:
+		return int(e.Size), e.Signed, nil
+	}
+	return 0, false, fmt.Errorf("%T is not an enum", typ)
+}
+
+func decodeInt(data []byte, signed bool) int64 {
+	var u uint64
+	switch len(data) {
+	case 1:
+		u = uint64(data[0])
+	case 2:
+		u = uint64(binary.LittleEndian.Uint16(data))
+	case 4:
+		u = uint64(binary.LittleEndian.Uint32(data))
+	case 8:
+		u = binary.LittleEndian.Uint64(data)
+	}
+	if signed {
+		switch len(data) {
+		case 1:
+			return int64(int8(u))
+		case 2:
+			return int64(int16(u))
+		case 4:
+			return int64(int32(u))
+		}
+	}
+	return int64(u)
+}
+
+// rodataBytes returns the size bytes of the .rodata section belonging to
+// varName, starting at offset bytes into that variable.
+func (i *ebpfInstance) rodataBytes(varName string, offset uint32, size int) ([]byte, error) {
+	var datasec *btf.Datasec
+	if err := i.collectionSpec.Types.TypeByName(".rodata", &datasec); err != nil {
+		return nil, fmt.Errorf("looking up .rodata section: %w", err)
+	}
+
+	var varOffset uint32
+	found := false
+	for _, v := range datasec.Vars {
+		btfVar, ok := v.Type.(*btf.Var)
+		if ok && btfVar.Name == varName {
+			varOffset = v.Offset
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("variable %q not found in .rodata", varName)
+	}
+
+	mapSpec, ok := i.collectionSpec.Maps[".rodata"]
+	if !ok || len(mapSpec.Contents) == 0 {
+		return nil, fmt.Errorf(".rodata map has no contents")
+	}
+
+	data, ok := mapSpec.Contents[0].Value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected .rodata contents type %T", mapSpec.Contents[0].Value)
+	}
+
+	start := int(varOffset + offset)
+	if start+size > len(data) {
+		return nil, fmt.Errorf("variable %q out of bounds of .rodata", varName)
+	}
+
+	return data[start : start+size], nil
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
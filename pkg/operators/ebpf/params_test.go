@@ -0,0 +1,104 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebpfoperator
+
+import (
+	"testing"
+
+	"github.com/cilium/ebpf/btf"
+	"github.com/stretchr/testify/require"
+
+	ebpftypes "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/ebpf/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+var u8 = &btf.Int{Name: "unsigned char", Size: 1, Encoding: btf.Unsigned}
+
+func TestGetTypeHintEnum(t *testing.T) {
+	enum := &btf.Enum{
+		Name: "fruit",
+		Size: 4,
+		Values: []btf.EnumValue{
+			{Name: "APPLE", Value: 0},
+			{Name: "BANANA", Value: 1},
+		},
+	}
+
+	require.Equal(t, params.TypeEnum, getTypeHint(enum, nil))
+	require.ElementsMatch(t, []string{"APPLE", "BANANA"}, enumeratorNames(enum))
+
+	name, ok := enumeratorForValue(enum, 1)
+	require.True(t, ok)
+	require.Equal(t, "BANANA", name)
+
+	_, ok = enumeratorForValue(enum, 42)
+	require.False(t, ok)
+}
+
+func TestGetTypeHintEnum64(t *testing.T) {
+	enum := &btf.Enum64{
+		Name: "bigFruit",
+		Size: 8,
+		Values: []btf.Enum64Value{
+			{Name: "MANGO", Value: 0},
+			{Name: "DURIAN", Value: 1},
+		},
+	}
+
+	require.Equal(t, params.TypeEnum, getTypeHint(enum, nil))
+	require.ElementsMatch(t, []string{"MANGO", "DURIAN"}, enumeratorNames(enum))
+}
+
+func TestGetTypeHintBitfield(t *testing.T) {
+	member := &btf.Member{
+		Name:         "flag",
+		Type:         u8,
+		BitfieldSize: 1,
+	}
+
+	require.Equal(t, params.TypeBool, getTypeHint(member.Type, member))
+}
+
+func TestGetTypeHintMultiBitBitfieldFallsBackToUnderlyingType(t *testing.T) {
+	member := &btf.Member{
+		Name:         "flags",
+		Type:         u8,
+		BitfieldSize: 3,
+	}
+
+	require.Equal(t, params.TypeUint8, getTypeHint(member.Type, member))
+}
+
+func TestGetTypeHintL4Endpoint(t *testing.T) {
+	l4Endpoint := &btf.Struct{
+		Name: ebpftypes.L4EndpointTypeName,
+	}
+
+	require.Equal(t, params.TypeL4Endpoint, getTypeHint(l4Endpoint, nil))
+}
+
+func TestGetTypeHintFixedByteArray(t *testing.T) {
+	// getTypeHint has no access to the gadget metadata, so fixed-size byte
+	// arrays default to raw bytes; addParam is what upgrades a u8[4]/u8[16]
+	// to params.TypeIP when the metadata annotates it as an address family.
+	ipv4 := &btf.Array{Type: u8, Nelems: 4}
+	require.Equal(t, params.TypeBytes, getTypeHint(ipv4, nil))
+
+	ipv6 := &btf.Array{Type: u8, Nelems: 16}
+	require.Equal(t, params.TypeBytes, getTypeHint(ipv6, nil))
+
+	opaque := &btf.Array{Type: u8, Nelems: 8}
+	require.Equal(t, params.TypeBytes, getTypeHint(opaque, nil))
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package params
+
+// TypeHint tells consumers (the CLI, the gadget service API, etc.) how to
+// parse, validate and present a parameter's value.
+type TypeHint string
+
+const (
+	TypeUnknown TypeHint = ""
+
+	TypeInt8   TypeHint = "int8"
+	TypeInt16  TypeHint = "int16"
+	TypeInt32  TypeHint = "int32"
+	TypeInt64  TypeHint = "int64"
+	TypeUint8  TypeHint = "uint8"
+	TypeUint16 TypeHint = "uint16"
+	TypeUint32 TypeHint = "uint32"
+	TypeUint64 TypeHint = "uint64"
+
+	TypeFloat32 TypeHint = "float32"
+	TypeFloat64 TypeHint = "float64"
+
+	TypeBool   TypeHint = "bool"
+	TypeString TypeHint = "string"
+
+	// TypeBytes represents an opaque fixed-size byte blob, rendered as hex.
+	TypeBytes TypeHint = "bytes"
+
+	// TypeIP represents an IPv4 or IPv6 address.
+	TypeIP TypeHint = "ip"
+
+	// TypeL4Endpoint represents a "host:port/proto" network endpoint.
+	TypeL4Endpoint TypeHint = "l4endpoint"
+
+	// TypeEnum represents a value restricted to a fixed set of names; see
+	// api.Param.PossibleValues for the allowed values of a given param.
+	TypeEnum TypeHint = "enum"
+)